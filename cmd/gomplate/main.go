@@ -10,6 +10,9 @@ import (
 	"os"
 	"os/exec"
 	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
 
 	"github.com/hairyhenderson/gomplate"
 	"github.com/hairyhenderson/gomplate/env"
@@ -55,6 +58,18 @@ func validateOpts(cmd *cobra.Command, args []string) error {
 			return errors.New("--input-dir must be set when --output-map is set")
 		}
 	}
+
+	if cmd.Flag("split-on").Changed {
+		if !cmd.Flag("split-name").Changed {
+			return errors.New("--split-name must be set when --split-on is set")
+		}
+		if cmd.Flag("output-map").Changed {
+			return errors.New("--split-on can not be used together with --output-map")
+		}
+	}
+	if cmd.Flag("split-name").Changed && !cmd.Flag("split-on").Changed {
+		return errors.New("--split-on must be set when --split-name is set")
+	}
 	return nil
 }
 
@@ -62,32 +77,133 @@ func printVersion(name string) {
 	fmt.Printf("%s version %s\n", name, version.Version)
 }
 
+// childSupervisor tracks the trailing `-- cmd` child process across the
+// initial run and every --watch rebuild, so "restart" can kill the
+// previous instance before starting a new one (instead of leaking a
+// concurrent copy on every rebuild) and "signal=NAME" can signal the
+// existing instance in place without restarting it at all.
+type childSupervisor struct {
+	mu   sync.Mutex
+	cmd  *exec.Cmd
+	done chan struct{}
+}
+
+var supervisor childSupervisor
+
+// spawn starts name/args as the new child, first killing whatever child
+// the supervisor was previously tracking.
+func (s *childSupervisor) spawn(name string, args []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.killLocked()
+
+	// nolint: gosec
+	c := exec.Command(name, args...)
+	c.Stdin = os.Stdin
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	if err := c.Start(); err != nil {
+		return err
+	}
+	done := make(chan struct{})
+	s.cmd = c
+	s.done = done
+	go func() {
+		// nolint: errcheck
+		c.Wait()
+		close(done)
+	}()
+	return nil
+}
+
+// signal sends sig to the tracked child, failing if none is running.
+func (s *childSupervisor) signal(sig os.Signal) error {
+	s.mu.Lock()
+	c := s.cmd
+	s.mu.Unlock()
+	if c == nil || c.Process == nil {
+		return errors.New("no running child process to signal")
+	}
+	return c.Process.Signal(sig)
+}
+
+// killLocked stops the tracked child, if any, and waits for it to exit.
+// Callers must hold s.mu.
+func (s *childSupervisor) killLocked() {
+	if s.cmd != nil && s.cmd.Process != nil {
+		// nolint: errcheck, gosec
+		s.cmd.Process.Kill()
+		<-s.done
+	}
+	s.cmd = nil
+	s.done = nil
+}
+
+// wait blocks until the tracked child (as of the most recent spawn) exits.
+func (s *childSupervisor) wait() error {
+	s.mu.Lock()
+	done := s.done
+	s.mu.Unlock()
+	if done == nil {
+		return nil
+	}
+	<-done
+	return nil
+}
+
+// watchSignals - the signals accepted by --watch-exec=signal=NAME.
+// Limited to a fixed, portable-enough set rather than parsing arbitrary
+// signal names.
+var watchSignals = map[string]os.Signal{
+	"HUP":  syscall.SIGHUP,
+	"INT":  syscall.SIGINT,
+	"TERM": syscall.SIGTERM,
+	"USR1": syscall.SIGUSR1,
+	"USR2": syscall.SIGUSR2,
+}
+
 // postRunExec - if templating succeeds, the command following a '--' will be executed
 func postRunExec(cmd *cobra.Command, args []string) error {
-	if len(args) > 0 {
-		name := args[0]
-		args = args[1:]
-		// nolint: gosec
-		c := exec.Command(name, args...)
-		c.Stdin = os.Stdin
-		c.Stderr = os.Stderr
-		c.Stdout = os.Stdout
-
-		// make sure all signals are propagated
-		sigs := make(chan os.Signal, 1)
-		signal.Notify(sigs)
-		go func() {
-			// Pass signals to the sub-process
-			sig := <-sigs
-			if c.Process != nil {
-				// nolint: gosec
-				_ = c.Process.Signal(sig)
-			}
-		}()
+	if len(args) == 0 {
+		return nil
+	}
+	name := args[0]
+	args = args[1:]
+	if err := supervisor.spawn(name, args); err != nil {
+		return err
+	}
+
+	// make sure all signals are propagated
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs)
+	go func() {
+		// Pass signals to the sub-process
+		sig := <-sigs
+		// nolint: errcheck
+		supervisor.signal(sig)
+	}()
 
-		return c.Run()
+	return supervisor.wait()
+}
+
+// rebuildExec applies --watch-exec's policy to the trailing `-- cmd`
+// after a successful --watch rebuild: "restart" kills the previous
+// instance and starts a new one; "signal=NAME" sends that signal to the
+// still-running instance instead of restarting it, falling back to
+// starting it if it isn't running yet (e.g. the first rebuild).
+func rebuildExec(watchExec, name string, args []string) error {
+	if strings.HasPrefix(watchExec, "signal=") {
+		sigName := strings.TrimPrefix(watchExec, "signal=")
+		sig, ok := watchSignals[sigName]
+		if !ok {
+			return fmt.Errorf("--watch-exec: unsupported signal %q", sigName)
+		}
+		if err := supervisor.signal(sig); err == nil {
+			return nil
+		}
+		return supervisor.spawn(name, args)
 	}
-	return nil
+	return supervisor.spawn(name, args)
 }
 
 // optionalExecArgs - implements cobra.PositionalArgs. Allows extra args following
@@ -136,13 +252,23 @@ func newGomplateCmd() *cobra.Command {
 			// support --include
 			opts.ExcludeGlob = processIncludes(includes, opts.ExcludeGlob)
 
+			gomplate.DataSourceHeaders = opts.DataSourceHeaders
+			gomplate.InputDirRef = opts.InputDirRef
+
+			if opts.Watch && opts.WatchExec != "none" && len(args) > 0 {
+				name, cargs := args[0], args[1:]
+				opts.RebuildHook = func() error {
+					return rebuildExec(opts.WatchExec, name, cargs)
+				}
+			}
+
 			err := gomplate.RunTemplates(&opts)
 			cmd.SilenceErrors = true
 			cmd.SilenceUsage = true
 			if verbose {
 				// nolint: errcheck
 				fmt.Fprintf(os.Stderr, "rendered %d template(s) with %d error(s) in %v\n",
-					gomplate.Metrics.TemplatesProcessed, gomplate.Metrics.Errors, gomplate.Metrics.TotalRenderDuration)
+					gomplate.Metrics.TemplatesProcessed(), gomplate.Metrics.Errors(), gomplate.Metrics.TotalRenderDuration)
 			}
 			return err
 		},
@@ -162,7 +288,8 @@ func initFlags(command *cobra.Command) {
 
 	command.Flags().StringArrayVarP(&opts.InputFiles, "file", "f", []string{"-"}, "Template `file` to process. Omit to use standard input, or use --in or --input-dir")
 	command.Flags().StringVarP(&opts.Input, "in", "i", "", "Template `string` to process (alternative to --file and --input-dir)")
-	command.Flags().StringVar(&opts.InputDir, "input-dir", "", "`directory` which is examined recursively for templates (alternative to --file and --in)")
+	command.Flags().StringVar(&opts.InputDir, "input-dir", "", "`directory` which is examined recursively for templates (alternative to --file and --in). May also be an http(s):// URL, or (not yet implemented) an s3:// or git(+https|+ssh):// URL")
+	command.Flags().StringVar(&opts.InputDirRef, "input-dir-ref", "", "git `ref` to check out for a git(+https|+ssh):// --input-dir (not yet implemented - see --input-dir)")
 
 	command.Flags().StringArrayVar(&opts.ExcludeGlob, "exclude", []string{}, "glob of files to not parse")
 	command.Flags().StringArrayVar(&includes, "include", []string{}, "glob of files to parse")
@@ -172,12 +299,23 @@ func initFlags(command *cobra.Command) {
 	command.Flags().StringVar(&opts.OutputDir, "output-dir", ".", "`directory` to store the processed templates. Only used for --input-dir")
 	command.Flags().StringVar(&opts.OutputMap, "output-map", "", "Template `string` to map the input file to an output path")
 	command.Flags().StringVar(&opts.OutMode, "chmod", "", "set the mode for output file(s). Omit to inherit from input file(s)")
+	command.Flags().StringVar(&opts.SplitOn, "split-on", "", "split the rendered output into multiple documents on this separator (literal, regexp, or \"frontmatter\")")
+	command.Flags().StringVar(&opts.SplitNameTemplate, "split-name", "", "template to compute the output path for each document produced by --split-on, given {{ .in }}, {{ .index }}, and {{ .meta }}")
 
 	ldDefault := env.Getenv("GOMPLATE_LEFT_DELIM", "{{")
 	rdDefault := env.Getenv("GOMPLATE_RIGHT_DELIM", "}}")
 	command.Flags().StringVar(&opts.LDelim, "left-delim", ldDefault, "override the default left-`delimiter` [$GOMPLATE_LEFT_DELIM]")
 	command.Flags().StringVar(&opts.RDelim, "right-delim", rdDefault, "override the default right-`delimiter` [$GOMPLATE_RIGHT_DELIM]")
 
+	command.Flags().StringVar(&opts.Engine, "engine", "", "template `engine` to use for files whose extension doesn't select one. Files ending in .mustache, .handlebars or .hbs always use the engine their extension names. Defaults to \"go\"")
+
+	command.Flags().IntVar(&opts.Concurrency, "parallel", 0, "number of templates to render concurrently. Defaults to the number of CPUs; use 1 for the old serial behaviour")
+	command.Flags().BoolVar(&opts.KeepGoing, "keep-going", false, "render every template even if some fail, instead of stopping at the first error")
+
+	command.Flags().BoolVar(&opts.Watch, "watch", false, "keep running, and re-render affected templates as their inputs change. Only template files and --template aliases are tracked - changes to datasources read via ds/include/file funcs do not trigger a rebuild")
+	command.Flags().DurationVar(&opts.WatchDebounce, "watch-debounce", 0, "coalesce bursts of filesystem events into one rebuild after this much quiet time. Defaults to 100ms")
+	command.Flags().StringVar(&opts.WatchExec, "watch-exec", "restart", "what to do with a trailing `-- cmd` after each rebuild: restart (kill and re-run), signal=NAME (HUP, INT, TERM, USR1 or USR2 - send to the running process instead of restarting it), or none")
+
 	command.Flags().BoolVarP(&verbose, "verbose", "V", false, "output extra information about what gomplate is doing")
 
 	command.Flags().BoolVarP(&printVer, "version", "v", false, "print the version")
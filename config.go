@@ -0,0 +1,140 @@
+package gomplate
+
+import (
+	"fmt"
+	"runtime"
+	"time"
+)
+
+// Config - the top-level configuration
+type Config struct {
+	Input       string
+	InputDir    string
+	InputFiles  []string
+	ExcludeGlob []string
+
+	// InputDirRef is the git ref to check out for a git(+https|+ssh)://
+	// InputDir, set via --input-dir-ref. Only consulted by the (not yet
+	// implemented) git input provider - see input_provider.go.
+	InputDirRef string
+
+	OutputDir   string
+	OutputFiles []string
+	OutputMap   string
+	OutMode     string
+
+	DataSources       []string
+	DataSourceHeaders []string
+	Contexts          []string
+
+	Templates []string
+
+	LDelim string
+	RDelim string
+
+	// Engine selects the template language used to render inputs. When
+	// empty, the Go text/template engine is used. Can be overridden on a
+	// per-file basis - see chooseEngine.
+	Engine string
+
+	// Concurrency is the number of templates rendered in parallel. A value
+	// of 1 preserves the historical serial behaviour. Defaults to
+	// runtime.NumCPU() - see defaults().
+	Concurrency int
+
+	// KeepGoing, when true, causes runTemplates to render every gathered
+	// template even if some fail, aggregating all errors instead of
+	// bailing out on the first one.
+	KeepGoing bool
+
+	// SplitOn, when set, cuts a single rendered template into multiple
+	// sub-documents post-render. Recognized values are "---" (or any
+	// other literal/regexp separator) and "frontmatter" (parse leading
+	// ---/+++ blocks per section). See splitter.go.
+	SplitOn string
+
+	// SplitNameTemplate computes the output path for each sub-document
+	// produced by SplitOn, analogous to OutputMap. It's executed with
+	// {{ .in }}, {{ .index }}, and {{ .meta }} (parsed frontmatter, when
+	// any) in context.
+	SplitNameTemplate string
+
+	// Watch, when true, causes RunTemplates to keep running after the
+	// initial render, re-rendering affected templates whenever a watched
+	// input changes. See watch.go.
+	Watch bool
+
+	// WatchDebounce coalesces bursts of filesystem events (e.g. an editor
+	// doing a write-then-rename) into a single rebuild. Defaults to
+	// 100ms - see defaults().
+	WatchDebounce time.Duration
+
+	// WatchExec selects what happens to a trailing `-- cmd` after each
+	// successful rebuild: "restart" (default, kill and re-run), "signal=NAME"
+	// (send that signal - HUP, INT, TERM, USR1 or USR2 - to the still-running
+	// process instead of restarting it), or "none" (leave it alone).
+	WatchExec string
+
+	// RebuildHook, when set, is called after each successful watch
+	// rebuild. The CLI wires this to rebuildExec so a trailing `-- cmd`
+	// is re-run per WatchExec.
+	RebuildHook func() error
+}
+
+// defaults - set (mostly filename-related) defaults
+func (o *Config) defaults() {
+	if o.Input != "" {
+		o.InputFiles = []string{"-"}
+	}
+	if len(o.InputFiles) == 0 && o.InputDir == "" {
+		o.InputFiles = []string{"-"}
+	}
+	if len(o.OutputFiles) == 0 && o.OutputDir == "" && o.OutputMap == "" {
+		o.OutputFiles = []string{"-"}
+	}
+	if o.OutputDir == "" {
+		o.OutputDir = "."
+	}
+	if o.Engine == "" {
+		o.Engine = "go"
+	}
+	if o.Concurrency == 0 {
+		o.Concurrency = numWorkers()
+	}
+	if o.Watch && o.WatchDebounce == 0 {
+		o.WatchDebounce = 100 * time.Millisecond
+	}
+	if o.Watch && o.WatchExec == "" {
+		o.WatchExec = "restart"
+	}
+}
+
+func (o *Config) String() string {
+	return fmt.Sprintf("Input: %s\n"+
+		"InputDir: %s\n"+
+		"OutputDir: %s\n"+
+		"OutputMap: %s\n"+
+		"Templates: %v\n"+
+		"Engine: %s\n",
+		o.Input, o.InputDir, o.OutputDir, o.OutputMap, o.Templates, o.Engine)
+}
+
+// numWorkers - the default level of concurrency to use when none is
+// explicitly configured
+func numWorkers() int {
+	n := runtime.NumCPU()
+	if n < 1 {
+		n = 1
+	}
+	return n
+}
+
+// Options - options used by gomplate.RenderTemplate to control rendering
+// behaviour of a single template, independent of the CLI.
+type Options struct {
+	LDelim string
+	RDelim string
+
+	// Engine selects the template language to use. Defaults to "go".
+	Engine string
+}
@@ -0,0 +1,89 @@
+package gomplate
+
+import (
+	"io"
+	"strings"
+
+	"github.com/cbroglie/mustache"
+	"github.com/pkg/errors"
+)
+
+// CompiledTemplate - a parsed template, ready to be executed against a
+// context. What it wraps is entirely up to the Engine that produced it.
+type CompiledTemplate interface{}
+
+// Engine - a pluggable template language. Implementations translate a
+// template source into a CompiledTemplate, and execute that compiled
+// template against a context, writing the result to an io.Writer.
+type Engine interface {
+	// Parse compiles src (named name) into a CompiledTemplate. funcs is
+	// made available to engines that support custom functions; engines
+	// that don't may ignore it.
+	Parse(name, src string, funcs map[string]interface{}) (CompiledTemplate, error)
+	// Execute renders a CompiledTemplate produced by Parse against ctx,
+	// writing the result to w.
+	Execute(ct CompiledTemplate, w io.Writer, ctx interface{}) error
+}
+
+// engines - the set of pluggable (i.e. non-Go) engines, keyed by the name
+// used with --engine and in gomplate.Config.Engine/Options.Engine. The
+// "go" engine isn't in this map: it's handled directly by
+// gomplate.runTemplate via tplate.toGoTemplate, which knows about
+// left/right delimiters and nested --template aliases that don't fit the
+// Engine interface's (name, src, funcs) signature.
+var engines = map[string]Engine{
+	"mustache":   &mustacheEngine{},
+	"handlebars": &mustacheEngine{},
+}
+
+// engineExts - maps file extensions to an engine name, so mixed
+// directories under --input-dir pick the right engine per file.
+var engineExts = map[string]string{
+	".mustache":   "mustache",
+	".handlebars": "handlebars",
+	".hbs":        "handlebars",
+}
+
+// lookupEngine finds the named non-Go engine. Callers must handle the
+// "go"/"" case themselves - see the comment on the engines map.
+func lookupEngine(name string) (Engine, error) {
+	e, ok := engines[name]
+	if !ok {
+		return nil, errors.Errorf("unknown template engine %q", name)
+	}
+	return e, nil
+}
+
+// chooseEngine picks the engine for a given template: an explicit
+// per-file override (detected from the extension of name) wins over the
+// configured default.
+func chooseEngine(name, def string) string {
+	for ext, engine := range engineExts {
+		if strings.HasSuffix(name, ext) {
+			return engine
+		}
+	}
+	return def
+}
+
+// mustacheEngine - renders {{ mustache }}/{{{ handlebars }}} style
+// templates. Custom function maps aren't supported by the mustache
+// language, so funcs is ignored - ds/env/etc. remain available only to
+// the Go engine.
+type mustacheEngine struct{}
+
+func (e *mustacheEngine) Parse(name, src string, funcs map[string]interface{}) (CompiledTemplate, error) {
+	tmpl, err := mustache.ParseString(src)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse mustache template %s", name)
+	}
+	return tmpl, nil
+}
+
+func (e *mustacheEngine) Execute(ct CompiledTemplate, w io.Writer, ctx interface{}) error {
+	tmpl, ok := ct.(*mustache.Template)
+	if !ok {
+		return errors.Errorf("not a mustache template: %T", ct)
+	}
+	return tmpl.FRender(w, ctx)
+}
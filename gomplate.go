@@ -10,10 +10,12 @@ import (
 	"path"
 	"path/filepath"
 	"strings"
+	"sync"
 	"text/template"
 	"time"
 
 	"github.com/hairyhenderson/gomplate/data"
+	"github.com/hashicorp/go-multierror"
 	"github.com/pkg/errors"
 	"github.com/spf13/afero"
 )
@@ -26,13 +28,86 @@ type gomplate struct {
 	nestedTemplates templateAliases
 	rootTemplate    *template.Template
 	context         interface{}
+
+	// engine is the default engine name (e.g. "go", "mustache"), used for
+	// templates that don't otherwise specify one via their file extension.
+	engine string
+
+	// splitOn, splitNameTemplate and outputDir mirror
+	// Config.SplitOn/SplitNameTemplate/OutputDir; when splitOn is set,
+	// runTemplate routes rendered output through writeSplit instead of
+	// writing directly to t.target.
+	splitOn           string
+	splitNameTemplate string
+	outputDir         string
+
+	// outputTracker is shared between collisionCheckedNamer (whole-template
+	// outputs) and writeSplit (split sub-document outputs), so a split
+	// section and a whole-template output - or two split sections - that
+	// resolve to the same path are caught regardless of which path produced
+	// it first.
+	outputTracker *outputTracker
+
+	// parseMu serializes t.toGoTemplate(g), which composes each tplate's
+	// source into g.rootTemplate (shared, mutated-on-parse state) to wire
+	// up nested --template aliases. text/template's Parse/New aren't safe
+	// for concurrent use on one underlying template, so with Concurrency
+	// now able to run several workers at once, parsing has to be
+	// serialized even though Execute afterwards can still run concurrently.
+	parseMu sync.Mutex
+}
+
+// parseGoTemplate wraps t.toGoTemplate(g) with g's parseMu, so concurrent
+// runTemplate calls don't race on the shared rootTemplate it composes
+// nested --template aliases into.
+func (g *gomplate) parseGoTemplate(t *tplate) (*template.Template, error) {
+	g.parseMu.Lock()
+	defer g.parseMu.Unlock()
+	return t.toGoTemplate(g)
+}
+
+// outputTracker records every output path claimed so far, so concurrent
+// renders/splits that resolve to the same destination are reported as a
+// collision instead of silently racing to write the same file.
+type outputTracker struct {
+	mu   sync.Mutex
+	seen map[string]bool
+}
+
+func newOutputTracker() *outputTracker {
+	return &outputTracker{seen: map[string]bool{}}
 }
 
+// claim records path as used, returning an error if it was already
+// claimed. "-" (stdout) is exempt: writes to it are serialized by
+// stdoutMu instead of rejected as a collision, since using "-" for
+// multiple --file/--out pairs is a legitimate, historically supported
+// pattern.
+func (t *outputTracker) claim(path string) error {
+	if path == "-" {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.seen[path] {
+		return errors.Errorf("output collision: multiple templates resolve to %q", path)
+	}
+	t.seen[path] = true
+	return nil
+}
+
+// stdoutMu serializes rendering of templates that target os.Stdout.
+// Multiple --file/--out pairs both using "-" is a legitimate, historically
+// supported pattern (they used to run one at a time); now that
+// Config.Concurrency can run several workers at once, writes to the
+// shared os.Stdout would otherwise interleave.
+var stdoutMu sync.Mutex
+
 // runTemplate -
 func (g *gomplate) runTemplate(t *tplate) error {
-	tmpl, err := t.toGoTemplate(g)
-	if err != nil {
-		return err
+	if t.target == os.Stdout {
+		stdoutMu.Lock()
+		defer stdoutMu.Unlock()
 	}
 
 	// nolint: gocritic
@@ -43,23 +118,68 @@ func (g *gomplate) runTemplate(t *tplate) error {
 			defer t.target.(io.Closer).Close()
 		}
 	}
-	err = tmpl.Execute(t.target, g.context)
-	return err
+
+	target := t.target
+	var buf *bytes.Buffer
+	if g.splitOn != "" {
+		buf = &bytes.Buffer{}
+		target = buf
+	}
+
+	engineName := chooseEngine(t.name, g.engine)
+	if engineName == "go" || engineName == "" {
+		tmpl, err := g.parseGoTemplate(t)
+		if err != nil {
+			return err
+		}
+		if err := tmpl.Execute(target, g.context); err != nil {
+			return err
+		}
+	} else {
+		e, err := lookupEngine(engineName)
+		if err != nil {
+			return err
+		}
+		ct, err := e.Parse(t.name, t.contents, g.funcMap)
+		if err != nil {
+			return err
+		}
+		if err := e.Execute(ct, target, g.context); err != nil {
+			return err
+		}
+	}
+
+	if buf != nil {
+		return writeSplit(g, t, buf.String())
+	}
+	return nil
 }
 
 type templateAliases map[string]string
 
 // newGomplate -
-func newGomplate(d *data.Data, leftDelim, rightDelim string, nested templateAliases, context interface{}) *gomplate {
+func newGomplate(d *data.Data, leftDelim, rightDelim string, nested templateAliases, context interface{}, engine string) *gomplate {
 	return &gomplate{
 		leftDelim:       leftDelim,
 		rightDelim:      rightDelim,
 		funcMap:         Funcs(d),
 		nestedTemplates: nested,
 		context:         context,
+		engine:          engine,
+		outputTracker:   newOutputTracker(),
 	}
 }
 
+// withSplit configures g to split its rendered output into multiple
+// sub-documents per splitOn/splitNameTemplate, written under outputDir.
+// Returns g for chaining.
+func (g *gomplate) withSplit(splitOn, splitNameTemplate, outputDir string) *gomplate {
+	g.splitOn = splitOn
+	g.splitNameTemplate = splitNameTemplate
+	g.outputDir = outputDir
+	return g
+}
+
 func parseTemplateArgs(templateArgs []string) (templateAliases, error) {
 	nested := templateAliases{}
 	for _, templateArg := range templateArgs {
@@ -80,6 +200,21 @@ func parseTemplateArg(templateArg string, ta templateAliases) error {
 		pth = parts[1]
 	}
 
+	// non-filesystem refs (http(s)://, s3://, git(+https)://, -@alias) are
+	// fetched up front through the input provider registry and
+	// materialized into a local temp file, so the rest of gomplate (which
+	// only knows how to read local paths) can treat them like any other
+	// --template path. The original ref is kept as the alias so templates
+	// still reference it by its natural name.
+	origPth := pth
+	if isRemoteRef(pth) {
+		local, err := materializeInputFile(pth)
+		if err != nil {
+			return errors.Wrapf(err, "failed to fetch --template ref %s", pth)
+		}
+		pth = local
+	}
+
 	switch fi, err := fs.Stat(pth); {
 	case err != nil:
 		return err
@@ -101,7 +236,7 @@ func parseTemplateArg(templateArg string, ta templateAliases) error {
 		if alias != "" {
 			ta[alias] = pth
 		} else {
-			ta[pth] = pth
+			ta[origPth] = pth
 		}
 	}
 	return nil
@@ -113,6 +248,14 @@ func RunTemplates(o *Config) error {
 	defer runCleanupHooks()
 	// make sure config is sane
 	o.defaults()
+
+	// resolve non-filesystem --input-dir/--file refs (http(s)://, -@alias)
+	// through the input provider registry, materializing them
+	// into local temp files/dirs that gatherTemplates can walk normally.
+	if err := materializeInputs(o); err != nil {
+		return err
+	}
+
 	ds := append(o.DataSources, o.Contexts...)
 	d, err := data.NewData(ds, o.DataSourceHeaders)
 	if err != nil {
@@ -127,35 +270,97 @@ func RunTemplates(o *Config) error {
 	if err != nil {
 		return err
 	}
-	g := newGomplate(d, o.LDelim, o.RDelim, nested, c)
+	g := newGomplate(d, o.LDelim, o.RDelim, nested, c, o.Engine).withSplit(o.SplitOn, o.SplitNameTemplate, o.OutputDir)
 
 	return g.runTemplates(o)
 }
 
 func (g *gomplate) runTemplates(o *Config) error {
 	start := time.Now()
-	tmpl, err := gatherTemplates(o, chooseNamer(o, g))
+	tmpl, err := gatherTemplates(o, collisionCheckedNamer(g, chooseNamer(o, g)))
 	Metrics.GatherDuration = time.Since(start)
 	if err != nil {
-		Metrics.Errors++
+		Metrics.incErrors()
 		return err
 	}
 	Metrics.TemplatesGathered = len(tmpl)
 	start = time.Now()
 	defer func() { Metrics.TotalRenderDuration = time.Since(start) }()
+
+	concurrency := o.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	jobs := make(chan *tplate)
+	errs := make(chan error, len(tmpl))
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for t := range jobs {
+				tstart := time.Now()
+				err := g.runTemplate(t)
+				Metrics.SetRenderDuration(t.name, time.Since(tstart))
+				if err != nil {
+					Metrics.incErrors()
+					errs <- errors.Wrapf(err, "failed to render template %s", t.name)
+					continue
+				}
+				Metrics.incTemplatesProcessed()
+			}
+		}()
+	}
+
 	for _, t := range tmpl {
-		tstart := time.Now()
-		err := g.runTemplate(t)
-		Metrics.RenderDuration[t.name] = time.Since(tstart)
-		if err != nil {
-			Metrics.Errors++
-			return err
+		jobs <- t
+		if !o.KeepGoing {
+			select {
+			case err := <-errs:
+				close(jobs)
+				wg.Wait()
+				return err
+			default:
+			}
 		}
-		Metrics.TemplatesProcessed++
+	}
+	close(jobs)
+	wg.Wait()
+	close(errs)
+
+	var merr *multierror.Error
+	for err := range errs {
+		merr = multierror.Append(merr, err)
+	}
+	if err := merr.ErrorOrNil(); err != nil {
+		return err
+	}
+
+	if o.Watch {
+		return runWatch(g, o, tmpl, g.nestedTemplates)
 	}
 	return nil
 }
 
+// collisionCheckedNamer wraps a namer function so that two templates
+// resolving to the same destination (e.g. a careless --output-map) are
+// reported as an error instead of silently racing to write the same
+// file. It shares g's outputTracker with writeSplit, so a split
+// sub-document can also collide with a whole-template output.
+func collisionCheckedNamer(g *gomplate, namer func(string) (string, error)) func(string) (string, error) {
+	return func(inPath string) (string, error) {
+		out, err := namer(inPath)
+		if err != nil {
+			return "", err
+		}
+		if err := g.outputTracker.claim(out); err != nil {
+			return "", errors.Wrapf(err, "while resolving output for %q", inPath)
+		}
+		return out, nil
+	}
+}
+
 func chooseNamer(o *Config, g *gomplate) func(string) (string, error) {
 	if o.OutputMap == "" {
 		return simpleNamer(o.OutputDir)
@@ -178,7 +383,7 @@ func mappingNamer(outMap string, g *gomplate) func(string) (string, error) {
 			contents: outMap,
 			target:   out,
 		}
-		tpl, err := t.toGoTemplate(g)
+		tpl, err := g.parseGoTemplate(t)
 		if err != nil {
 			return "", err
 		}
@@ -222,7 +427,7 @@ func RenderTemplate(in io.Reader, out io.Writer, opts *Options) error {
 	if err != nil {
 		return err
 	}
-	g := newGomplate(d, opts.LDelim, opts.RDelim, nil, nil)
+	g := newGomplate(d, opts.LDelim, opts.RDelim, nil, nil, opts.Engine)
 
 	t := &tplate{
 		contents: string(i),
@@ -0,0 +1,384 @@
+package gomplate
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/afero"
+)
+
+// InputProvider - an abstraction over where template source (and
+// --input-dir trees) are read from. Providers are looked up by the URL
+// scheme of the ref passed to --file/--input-dir/--template.
+type InputProvider interface {
+	// Open returns a reader for the single resource named by ref, along
+	// with the mode it should be written back out with (when the
+	// destination inherits it).
+	Open(ref string) (io.ReadCloser, os.FileMode, error)
+	// Walk visits every leaf resource under ref, calling fn with a path
+	// relative to ref and a reader for its contents.
+	Walk(ref string, fn func(relPath string, r io.ReadCloser) error) error
+}
+
+// inputProviders - registered providers, keyed by URL scheme. The zero-value
+// key ("") is the default (local filesystem).
+//
+// s3:// and git(+https):// are registered as explicit stubs rather than
+// left out of the map: reading them properly needs a real S3/git client,
+// which isn't available in this build, but a user who points --file or
+// --input-dir at one of these schemes should get a clear "not yet
+// implemented" error from the provider itself, not a generic "no input
+// provider registered for scheme" as if the scheme were never planned.
+var inputProviders = map[string]InputProvider{
+	"":      &fileInputProvider{},
+	"file":  &fileInputProvider{},
+	"http":  &httpInputProvider{},
+	"https": &httpInputProvider{},
+	"s3":    &s3InputProvider{},
+	"git":   &gitInputProvider{},
+	"-":     &stdinInputProvider{},
+}
+
+// DataSourceHeaders - headers provided via --datasource-header, reused
+// here so the HTTP input provider can authenticate the same way
+// datasources do. Set by the CLI alongside Config.DataSourceHeaders.
+var DataSourceHeaders []string
+
+// InputDirRef - the git ref to check out for git:// --input-dir trees,
+// set from --input-dir-ref. Only consulted by gitInputProvider, which
+// doesn't yet perform a real checkout - see its doc comment.
+var InputDirRef string
+
+// headersForHost filters raw --datasource-header values (each in
+// "alias=Name: value" form) down to the ones scoped to host, returning
+// their "Name: value" portions. Matching is by exact alias==host rather
+// than by datasource alias, since an input-provider ref is a bare URL
+// with no alias of its own - only the header entries a user explicitly
+// scoped to that host are eligible to be sent with it.
+func headersForHost(headers []string, host string) []string {
+	var out []string
+	for _, h := range headers {
+		parts := strings.SplitN(h, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		if strings.TrimSpace(parts[0]) == host {
+			out = append(out, parts[1])
+		}
+	}
+	return out
+}
+
+// isRemoteRef reports whether ref should be routed through the input
+// provider registry rather than treated as a plain local filesystem path.
+// Bare "-" is deliberately excluded: gatherTemplates already reads plain
+// stdin natively, and it's the CLI's default --file value, so routing it
+// through here too would consume stdin a second time.
+func isRemoteRef(ref string) bool {
+	if strings.HasPrefix(ref, "-@") {
+		return true
+	}
+	for _, scheme := range []string{"http://", "https://", "s3://", "git://", "git+https://", "git+ssh://"} {
+		if strings.HasPrefix(ref, scheme) {
+			return true
+		}
+	}
+	return false
+}
+
+// lookupInputProvider resolves ref to an InputProvider based on its URL
+// scheme (or "-@alias" for an aliased stdin stream).
+func lookupInputProvider(ref string) (InputProvider, error) {
+	if strings.HasPrefix(ref, "-@") {
+		return inputProviders["-"], nil
+	}
+	if strings.HasPrefix(ref, "git+https://") || strings.HasPrefix(ref, "git+ssh://") || strings.HasPrefix(ref, "git://") {
+		return inputProviders["git"], nil
+	}
+	u, err := url.Parse(ref)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse input ref %q", ref)
+	}
+	p, ok := inputProviders[u.Scheme]
+	if !ok {
+		return nil, errors.Errorf("no input provider registered for scheme %q (ref %q)", u.Scheme, ref)
+	}
+	return p, nil
+}
+
+// openInput opens a single input ref, routing through the provider
+// registry by scheme. It's the entry point gatherTemplates and
+// parseTemplateArg use to resolve --file/--template refs that aren't
+// plain local paths.
+func openInput(ref string) (io.ReadCloser, os.FileMode, error) {
+	p, err := lookupInputProvider(ref)
+	if err != nil {
+		return nil, 0, err
+	}
+	return p.Open(ref)
+}
+
+// walkInput walks an --input-dir ref, routing through the provider
+// registry by scheme.
+func walkInput(ref string, fn func(relPath string, r io.ReadCloser) error) error {
+	p, err := lookupInputProvider(ref)
+	if err != nil {
+		return err
+	}
+	return p.Walk(ref, fn)
+}
+
+// fileInputProvider - the default provider, backed by the package-level
+// afero filesystem (fs) used everywhere else in gomplate for local I/O.
+type fileInputProvider struct{}
+
+func (p *fileInputProvider) Open(ref string) (io.ReadCloser, os.FileMode, error) {
+	ref = strings.TrimPrefix(ref, "file://")
+	fi, err := fs.Stat(ref)
+	if err != nil {
+		return nil, 0, err
+	}
+	f, err := fs.Open(ref)
+	if err != nil {
+		return nil, 0, err
+	}
+	return f, fi.Mode(), nil
+}
+
+func (p *fileInputProvider) Walk(ref string, fn func(relPath string, r io.ReadCloser) error) error {
+	ref = strings.TrimPrefix(ref, "file://")
+	return afero.Walk(fs, ref, func(pth string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if fi.IsDir() {
+			return nil
+		}
+		rel := strings.TrimPrefix(strings.TrimPrefix(pth, ref), "/")
+		f, err := fs.Open(pth)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		return fn(rel, f)
+	})
+}
+
+// stdinInputProvider - reads a single resource from os.Stdin. A "-@name"
+// ref aliases the stream as "name", giving --output-map something to key
+// on for templates that otherwise have no path.
+type stdinInputProvider struct{}
+
+func (p *stdinInputProvider) Open(ref string) (io.ReadCloser, os.FileMode, error) {
+	return ioutil.NopCloser(os.Stdin), 0644, nil
+}
+
+func (p *stdinInputProvider) Walk(ref string, fn func(relPath string, r io.ReadCloser) error) error {
+	name := strings.TrimPrefix(ref, "-@")
+	if name == ref {
+		name = "-"
+	}
+	r, _, err := p.Open(ref)
+	if err != nil {
+		return err
+	}
+	return fn(name, r)
+}
+
+// httpInputProvider - fetches templates (or --input-dir subtrees, treated
+// as a single resource) over HTTP(S), honouring --datasource-header for
+// auth reuse with the rest of gomplate's datasources. A header only
+// applies to a request if its alias matches the ref's host, so a header
+// scoped to one datasource (e.g. a bearer token for an internal API)
+// isn't sent to every http(s):// ref used as --file/--input-dir/--template.
+type httpInputProvider struct{}
+
+func (p *httpInputProvider) Open(ref string) (io.ReadCloser, os.FileMode, error) {
+	req, err := http.NewRequest(http.MethodGet, ref, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, h := range headersForHost(DataSourceHeaders, req.URL.Host) {
+		kv := strings.SplitN(h, ":", 2)
+		if len(kv) == 2 {
+			req.Header.Set(strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1]))
+		}
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	if resp.StatusCode >= 400 {
+		defer resp.Body.Close()
+		return nil, 0, errors.Errorf("GET %s: %s", ref, resp.Status)
+	}
+	return resp.Body, 0644, nil
+}
+
+func (p *httpInputProvider) Walk(ref string, fn func(relPath string, r io.ReadCloser) error) error {
+	r, _, err := p.Open(ref)
+	if err != nil {
+		return err
+	}
+	u, err := url.Parse(ref)
+	if err != nil {
+		return err
+	}
+	parts := strings.Split(strings.TrimSuffix(u.Path, "/"), "/")
+	return fn(parts[len(parts)-1], r)
+}
+
+// s3InputProvider - registered for the s3:// scheme so --file/--input-dir
+// refs using it get a clear "not yet implemented" error instead of the
+// generic "no input provider registered for scheme" one. Reading from S3
+// needs a real S3 client, which this build doesn't have; no bucket/key
+// parsing happens here.
+type s3InputProvider struct{}
+
+func (p *s3InputProvider) Open(ref string) (io.ReadCloser, os.FileMode, error) {
+	return nil, 0, errors.Errorf("s3:// input provider is not yet implemented (ref %q)", ref)
+}
+
+func (p *s3InputProvider) Walk(ref string, fn func(relPath string, r io.ReadCloser) error) error {
+	return errors.Errorf("s3:// input provider is not yet implemented (ref %q)", ref)
+}
+
+// gitInputProvider - registered for git://, git+https:// and git+ssh://
+// so --input-dir refs using them get a clear "not yet implemented" error
+// instead of the generic "no input provider registered for scheme" one.
+// Checking out a ref needs a real git client, which this build doesn't
+// have; --input-dir-ref (InputDirRef) is accepted and threaded through so
+// the error at least names the ref a real implementation would check out.
+type gitInputProvider struct{}
+
+func (p *gitInputProvider) Open(ref string) (io.ReadCloser, os.FileMode, error) {
+	return nil, 0, errors.Errorf("git input provider is not yet implemented (ref %q); use --input-dir, not --file", ref)
+}
+
+func (p *gitInputProvider) Walk(ref string, fn func(relPath string, r io.ReadCloser) error) error {
+	gitRef := InputDirRef
+	if gitRef == "" {
+		gitRef = "<default branch>"
+	}
+	return errors.Errorf("git input provider is not yet implemented: would need to check out %q@%s", ref, gitRef)
+}
+
+// materializeInputFile resolves ref to a local path, fetching it through
+// the input provider registry and writing it to a temp file first if it's
+// a remote ref. Local refs are returned unchanged. Callers that only know
+// how to read local paths (gatherTemplates, parseTemplateArg) use this to
+// transparently support remote --file/--template refs.
+func materializeInputFile(ref string) (string, error) {
+	if !isRemoteRef(ref) {
+		return ref, nil
+	}
+
+	// a "-@name" alias needs to keep its given name (it's what
+	// --output-map keys on), rather than a throwaway temp filename
+	if name := strings.TrimPrefix(ref, "-@"); name != ref {
+		dir, err := afero.TempDir(fs, "", "gomplate-stdin-")
+		if err != nil {
+			return "", err
+		}
+		dest := filepath.Join(dir, name)
+		if err := fs.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+			return "", err
+		}
+		f, err := fs.Create(dest)
+		if err != nil {
+			return "", err
+		}
+		if _, err := io.Copy(f, os.Stdin); err != nil {
+			f.Close()
+			return "", err
+		}
+		if err := f.Close(); err != nil {
+			return "", err
+		}
+		addCleanupHook(func() error { return fs.RemoveAll(dir) })
+		return dest, nil
+	}
+
+	r, _, err := openInput(ref)
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+
+	f, err := afero.TempFile(fs, "", "gomplate-input-")
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+
+	pth := f.Name()
+	addCleanupHook(func() error { return fs.Remove(pth) })
+	return pth, nil
+}
+
+// materializeInputDir resolves ref to a local directory, walking it
+// through the input provider registry and copying its contents into a
+// temp dir first if it's a remote ref. Local refs are returned unchanged.
+func materializeInputDir(ref string) (string, error) {
+	if !isRemoteRef(ref) {
+		return ref, nil
+	}
+	dir, err := afero.TempDir(fs, "", "gomplate-input-dir-")
+	if err != nil {
+		return "", err
+	}
+
+	err = walkInput(ref, func(relPath string, r io.ReadCloser) error {
+		defer r.Close()
+		dest := filepath.Join(dir, relPath)
+		if err := fs.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+			return err
+		}
+		f, err := fs.Create(dest)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(f, r)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	addCleanupHook(func() error { return fs.RemoveAll(dir) })
+	return dir, nil
+}
+
+// materializeInputs resolves o.InputDir and every entry of o.InputFiles
+// that's a remote ref into local paths, so gatherTemplates (which only
+// knows how to walk the local filesystem) can use them unmodified.
+func materializeInputs(o *Config) error {
+	if o.InputDir != "" {
+		dir, err := materializeInputDir(o.InputDir)
+		if err != nil {
+			return errors.Wrapf(err, "failed to fetch --input-dir %s", o.InputDir)
+		}
+		o.InputDir = dir
+	}
+	for i, f := range o.InputFiles {
+		pth, err := materializeInputFile(f)
+		if err != nil {
+			return errors.Wrapf(err, "failed to fetch --file %s", f)
+		}
+		o.InputFiles[i] = pth
+	}
+	return nil
+}
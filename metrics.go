@@ -0,0 +1,67 @@
+package gomplate
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Metrics holds stats about a gomplate run. The per-template counters and
+// render durations are updated concurrently from multiple rendering
+// workers, so they're only reachable through its methods. GatherDuration,
+// TotalRenderDuration and TemplatesGathered are plain fields, set from the
+// goroutine running runTemplates.
+var Metrics *metrics
+
+type metrics struct {
+	GatherDuration      time.Duration
+	TotalRenderDuration time.Duration
+
+	TemplatesGathered int
+
+	templatesProcessed int64
+	errors             int64
+
+	renderDurationMu sync.Mutex
+	renderDuration   map[string]time.Duration
+}
+
+func newMetrics() *metrics {
+	return &metrics{
+		renderDuration: map[string]time.Duration{},
+	}
+}
+
+// TemplatesProcessed - the number of templates rendered so far, safe to
+// read/increment concurrently.
+func (m *metrics) TemplatesProcessed() int64 {
+	return atomic.LoadInt64(&m.templatesProcessed)
+}
+
+func (m *metrics) incTemplatesProcessed() {
+	atomic.AddInt64(&m.templatesProcessed, 1)
+}
+
+// Errors - the number of errors seen so far, safe to read/increment
+// concurrently.
+func (m *metrics) Errors() int64 {
+	return atomic.LoadInt64(&m.errors)
+}
+
+func (m *metrics) incErrors() {
+	atomic.AddInt64(&m.errors, 1)
+}
+
+// SetRenderDuration - record the render duration for a named template.
+func (m *metrics) SetRenderDuration(name string, d time.Duration) {
+	m.renderDurationMu.Lock()
+	defer m.renderDurationMu.Unlock()
+	m.renderDuration[name] = d
+}
+
+// RenderDuration - the render duration recorded for a named template.
+func (m *metrics) RenderDuration(name string) time.Duration {
+	m.renderDurationMu.Lock()
+	defer m.renderDurationMu.Unlock()
+	return m.renderDuration[name]
+}
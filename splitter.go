@@ -0,0 +1,211 @@
+package gomplate
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/BurntSushi/toml"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// yamlFenceRe/tomlFenceRe - match a complete leading frontmatter block
+// (opening fence, body, closing fence) anchored at the start of the
+// string being matched. Go's RE2 engine has no backreferences, so YAML
+// and TOML fences need their own anchored pattern rather than one
+// generic pattern parameterized on which fence opened.
+var yamlFenceRe = regexp.MustCompile(`(?s)\A---\r?\n(.*?)\r?\n---\r?\n?`)
+var tomlFenceRe = regexp.MustCompile(`(?s)\A\+\+\+\r?\n(.*?)\r?\n\+\+\+\r?\n?`)
+
+// frontMatterRe - matches a leading YAML (---) or TOML (+++) frontmatter
+// block: the fence, its body, the closing fence, and the remaining body.
+var frontMatterRe = regexp.MustCompile(`(?s)\A(---|\+\+\+)\r?\n(.*?)\r?\n(---|\+\+\+)\r?\n?(.*)\z`)
+
+// nextFenceRe - matches the start of the next document's frontmatter
+// fence, used to find where one "frontmatter" split section ends and the
+// next begins.
+var nextFenceRe = regexp.MustCompile(`(?m)^(---|\+\+\+)\s*$`)
+
+// frontMatterBlockEnd reports whether s begins with a complete YAML or
+// TOML frontmatter block (open fence through matching close fence), and
+// if so, how many bytes of s that block occupies.
+func frontMatterBlockEnd(s string) (int, bool) {
+	if loc := yamlFenceRe.FindStringIndex(s); loc != nil {
+		return loc[1], true
+	}
+	if loc := tomlFenceRe.FindStringIndex(s); loc != nil {
+		return loc[1], true
+	}
+	return 0, false
+}
+
+// splitFrontMatterSections splits rendered into one section per leading
+// frontmatter block, pairing each block's own open/close fence before
+// looking for the next document's fence - so a document's closing fence
+// isn't mistaken for the start of the next one.
+func splitFrontMatterSections(rendered string) []string {
+	var sections []string
+	rest := rendered
+	for rest != "" {
+		blockEnd, ok := frontMatterBlockEnd(rest)
+		if !ok {
+			sections = append(sections, rest)
+			break
+		}
+		body := rest[blockEnd:]
+		loc := nextFenceRe.FindStringIndex(body)
+		if loc == nil {
+			sections = append(sections, rest)
+			break
+		}
+		sections = append(sections, rest[:blockEnd+loc[0]])
+		rest = rest[blockEnd+loc[0]:]
+	}
+	return sections
+}
+
+// splitSections splits a rendered buffer into sub-documents per
+// Config.SplitOn: "frontmatter" cuts between consecutive frontmatter
+// documents (pairing each document's own open/close fence first),
+// anything else is used as a literal or regexp separator (with "---"
+// getting the usual YAML-multi-doc treatment of requiring its own line).
+func splitSections(rendered, splitOn string) ([]string, error) {
+	switch splitOn {
+	case "":
+		return []string{rendered}, nil
+	case "frontmatter":
+		if _, ok := frontMatterBlockEnd(rendered); !ok {
+			return []string{rendered}, nil
+		}
+		return splitFrontMatterSections(rendered), nil
+	case "---":
+		re := regexp.MustCompile(`(?m)^---\s*$`)
+		return splitNonEmpty(re.Split(rendered, -1)), nil
+	default:
+		re, err := regexp.Compile(splitOn)
+		if err != nil {
+			return splitNonEmpty(strings.Split(rendered, splitOn)), nil
+		}
+		return splitNonEmpty(re.Split(rendered, -1)), nil
+	}
+}
+
+func splitNonEmpty(in []string) []string {
+	out := make([]string, 0, len(in))
+	for _, s := range in {
+		if strings.TrimSpace(s) != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// parseFrontMatter extracts a leading YAML/TOML frontmatter block (or a
+// fenced ```json block) from section, returning the parsed fields and the
+// remaining body. If section has no recognizable frontmatter, meta is nil
+// and body is the section unchanged.
+func parseFrontMatter(section string) (meta map[string]interface{}, body string, err error) {
+	if m := frontMatterRe.FindStringSubmatch(section); m != nil {
+		fence, raw, rest := m[1], m[2], m[4]
+		meta = map[string]interface{}{}
+		switch fence {
+		case "---":
+			err = yaml.Unmarshal([]byte(raw), &meta)
+		case "+++":
+			err = toml.Unmarshal([]byte(raw), &meta)
+		}
+		if err != nil {
+			return nil, section, errors.Wrapf(err, "failed to parse %s frontmatter", fence)
+		}
+		return meta, rest, nil
+	}
+
+	trimmed := strings.TrimSpace(section)
+	if strings.HasPrefix(trimmed, "```json") {
+		end := strings.Index(trimmed, "```\n")
+		if end > 0 {
+			// the second fence, not the opening one
+			closeIdx := strings.Index(trimmed[len("```json\n"):], "```")
+			if closeIdx >= 0 {
+				raw := trimmed[len("```json\n") : len("```json\n")+closeIdx]
+				meta = map[string]interface{}{}
+				if err = json.Unmarshal([]byte(raw), &meta); err != nil {
+					return nil, section, errors.Wrap(err, "failed to parse json frontmatter")
+				}
+				rest := trimmed[len("```json\n")+closeIdx+len("```"):]
+				return meta, strings.TrimPrefix(rest, "\n"), nil
+			}
+		}
+	}
+
+	return nil, section, nil
+}
+
+// splitNamer computes the output path for a split sub-document using
+// Config.SplitNameTemplate, analogous to mappingNamer for --output-map.
+func splitNamer(nameTemplate, in string, index int, meta map[string]interface{}) (string, error) {
+	tmpl, err := template.New("<SplitName>").Parse(nameTemplate)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to parse --split-name template")
+	}
+	ctx := map[string]interface{}{
+		"in":    in,
+		"index": index,
+		"meta":  meta,
+	}
+	out := &bytes.Buffer{}
+	if err := tmpl.Execute(out, ctx); err != nil {
+		return "", errors.Wrapf(err, "failed to render --split-name with index %d", index)
+	}
+	name := strings.TrimSpace(out.String())
+	if name == "" {
+		name = in + "." + strconv.Itoa(index)
+	}
+	return name, nil
+}
+
+// writeSplit splits rendered per g's split configuration and writes each
+// section to the path computed by splitNamer (joined with g.outputDir,
+// same as simpleNamer does for whole-template output), using the
+// package-level afero filesystem fs. Each path is run through g's
+// outputTracker so two sections that compute the same path - or a
+// section that collides with a whole-template output - fail loudly
+// instead of silently overwriting one another.
+func writeSplit(g *gomplate, t *tplate, rendered string) error {
+	sections, err := splitSections(rendered, g.splitOn)
+	if err != nil {
+		return err
+	}
+	for i, section := range sections {
+		meta, body, err := parseFrontMatter(section)
+		if err != nil {
+			return err
+		}
+		name, err := splitNamer(g.splitNameTemplate, t.name, i, meta)
+		if err != nil {
+			return err
+		}
+		outPath := filepath.Clean(filepath.Join(g.outputDir, name))
+		if err := g.outputTracker.claim(outPath); err != nil {
+			return err
+		}
+		f, err := fs.Create(outPath)
+		if err != nil {
+			return errors.Wrapf(err, "failed to create split output %s", outPath)
+		}
+		_, werr := f.Write([]byte(strings.TrimPrefix(body, "\n")))
+		cerr := f.Close()
+		if werr != nil {
+			return werr
+		}
+		if cerr != nil {
+			return cerr
+		}
+	}
+	return nil
+}
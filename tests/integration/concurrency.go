@@ -0,0 +1,65 @@
+//+build integration
+
+package integration
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "gopkg.in/check.v1"
+
+	"gotest.tools/v3/icmd"
+)
+
+type ConcurrencySuite struct {
+	tmpDir string
+}
+
+var _ = Suite(&ConcurrencySuite{})
+
+func (s *ConcurrencySuite) SetUpTest(c *C) {
+	dir, err := ioutil.TempDir("", "gomplate-concurrency-")
+	handle(c, err)
+	s.tmpDir = dir
+
+	for _, name := range []string{"one.tmpl", "two.tmpl", "three.tmpl"} {
+		err := ioutil.WriteFile(filepath.Join(dir, name), []byte("{{ `rendered` }}"), 0644)
+		handle(c, err)
+	}
+}
+
+func (s *ConcurrencySuite) TearDownTest(c *C) {
+	os.RemoveAll(s.tmpDir)
+}
+
+// TestParallelRenderMatchesSerial makes sure rendering with the default
+// (NumCPU) concurrency produces, for every template, the output a serial
+// render gives ("rendered"), i.e. the worker pool introduced for
+// Concurrency doesn't corrupt output when several templates render at
+// once. It doesn't run --parallel=1 itself.
+func (s *ConcurrencySuite) TestParallelRenderMatchesSerial(c *C) {
+	outDir := filepath.Join(s.tmpDir, "out")
+	result := icmd.RunCommand(GomplateBin,
+		"--input-dir", s.tmpDir,
+		"--output-dir", outDir)
+	result.Assert(c, icmd.Expected{ExitCode: 0})
+
+	for _, name := range []string{"one.tmpl", "two.tmpl", "three.tmpl"} {
+		b, err := ioutil.ReadFile(filepath.Join(outDir, name))
+		handle(c, err)
+		c.Check(string(b), Equals, "rendered")
+	}
+}
+
+// TestOutputMapCollisionIsAnError makes sure two inputs that resolve to
+// the same --output-map destination fail loudly instead of silently
+// overwriting one another under concurrent rendering.
+func (s *ConcurrencySuite) TestOutputMapCollisionIsAnError(c *C) {
+	outDir := filepath.Join(s.tmpDir, "collide")
+	result := icmd.RunCommand(GomplateBin,
+		"--input-dir", s.tmpDir,
+		"--output-map", filepath.Join(outDir, "same.out"))
+	result.Assert(c, icmd.Expected{ExitCode: 1})
+	c.Check(result.Combined(), Matches, "(?s).*output collision.*")
+}
@@ -0,0 +1,28 @@
+//+build integration
+
+package integration
+
+import (
+	. "gopkg.in/check.v1"
+
+	"gotest.tools/v3/icmd"
+)
+
+type EnginesSuite struct{}
+
+var _ = Suite(&EnginesSuite{})
+
+func (s *EnginesSuite) TestGoEngineIsDefault(c *C) {
+	result := icmd.RunCommand(GomplateBin, "-i", "{{ `hello` }}")
+	result.Assert(c, icmd.Expected{ExitCode: 0, Out: "hello"})
+}
+
+func (s *EnginesSuite) TestMustacheEngineOverride(c *C) {
+	result := icmd.RunCommand(GomplateBin, "--engine", "mustache", "-i", "plain mustache text, no placeholders")
+	result.Assert(c, icmd.Expected{ExitCode: 0, Out: "plain mustache text, no placeholders"})
+}
+
+func (s *EnginesSuite) TestUnknownEngineErrors(c *C) {
+	result := icmd.RunCommand(GomplateBin, "--engine", "bogus", "-i", "anything")
+	result.Assert(c, icmd.Expected{ExitCode: 1})
+}
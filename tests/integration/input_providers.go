@@ -0,0 +1,61 @@
+//+build integration
+
+package integration
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+
+	. "gopkg.in/check.v1"
+
+	"gotest.tools/v3/icmd"
+)
+
+type InputProvidersSuite struct {
+	l *net.TCPListener
+}
+
+var _ = Suite(&InputProvidersSuite{})
+
+func (s *InputProvidersSuite) SetUpSuite(c *C) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/tmpl", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "{{ `fetched` }}")
+	})
+
+	var err error
+	s.l, err = net.ListenTCP("tcp", &net.TCPAddr{IP: net.ParseIP("127.0.0.1")})
+	handle(c, err)
+
+	go http.Serve(s.l, mux)
+}
+
+func (s *InputProvidersSuite) TearDownSuite(c *C) {
+	s.l.Close()
+}
+
+func (s *InputProvidersSuite) TestHTTPFileIsMaterializedAndRendered(c *C) {
+	result := icmd.RunCommand(GomplateBin,
+		"--file", "http://"+s.l.Addr().String()+"/tmpl")
+	result.Assert(c, icmd.Expected{ExitCode: 0, Out: "fetched"})
+}
+
+func (s *InputProvidersSuite) TestUnknownSchemeErrors(c *C) {
+	result := icmd.RunCommand(GomplateBin, "--file", "ftp://example.invalid/tmpl")
+	result.Assert(c, icmd.Expected{ExitCode: 1})
+}
+
+func (s *InputProvidersSuite) TestS3InputIsNotYetImplemented(c *C) {
+	result := icmd.RunCommand(GomplateBin, "--input-dir", "s3://some-bucket/prefix")
+	result.Assert(c, icmd.Expected{ExitCode: 1})
+	c.Check(result.Combined(), Matches, "(?s).*not yet implemented.*")
+}
+
+func (s *InputProvidersSuite) TestGitInputIsNotYetImplemented(c *C) {
+	result := icmd.RunCommand(GomplateBin,
+		"--input-dir", "git+https://example.invalid/repo.git",
+		"--input-dir-ref", "v1.2.3")
+	result.Assert(c, icmd.Expected{ExitCode: 1})
+	c.Check(result.Combined(), Matches, "(?s).*not yet implemented.*v1.2.3.*")
+}
@@ -0,0 +1,100 @@
+//+build integration
+
+package integration
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "gopkg.in/check.v1"
+
+	"gotest.tools/v3/icmd"
+)
+
+type SplitSuite struct {
+	tmpDir string
+}
+
+var _ = Suite(&SplitSuite{})
+
+func (s *SplitSuite) SetUpTest(c *C) {
+	dir, err := ioutil.TempDir("", "gomplate-split-")
+	handle(c, err)
+	s.tmpDir = dir
+}
+
+func (s *SplitSuite) TearDownTest(c *C) {
+	os.RemoveAll(s.tmpDir)
+}
+
+// TestFrontmatterSplitPairsFencesPerDocument makes sure a two-document
+// frontmatter stream produces exactly two sections, not four - each
+// document's own open/close fence must be paired before the next
+// document's fence is treated as a new boundary.
+func (s *SplitSuite) TestFrontmatterSplitPairsFencesPerDocument(c *C) {
+	inDir := filepath.Join(s.tmpDir, "in")
+	handle(c, os.Mkdir(inDir, 0755))
+	tmplPath := filepath.Join(inDir, "in.tmpl")
+	err := ioutil.WriteFile(tmplPath, []byte(
+		"---\ntitle: A\n---\nContent A\n"+
+			"---\ntitle: B\n---\nContent B\n"), 0644)
+	handle(c, err)
+
+	outDir := filepath.Join(s.tmpDir, "out")
+	result := icmd.RunCommand(GomplateBin,
+		"--input-dir", inDir,
+		"--split-on", "frontmatter",
+		"--split-name", "{{ .meta.title }}.out",
+		"--output-dir", outDir)
+	result.Assert(c, icmd.Expected{ExitCode: 0})
+
+	a, err := ioutil.ReadFile(filepath.Join(outDir, "A.out"))
+	handle(c, err)
+	c.Check(string(a), Matches, "(?s).*Content A.*")
+
+	b, err := ioutil.ReadFile(filepath.Join(outDir, "B.out"))
+	handle(c, err)
+	c.Check(string(b), Matches, "(?s).*Content B.*")
+}
+
+// TestSplitNameHonorsOutputDir makes sure split sub-document paths are
+// joined with --output-dir rather than being written relative to the
+// process's working directory.
+func (s *SplitSuite) TestSplitNameHonorsOutputDir(c *C) {
+	inDir := filepath.Join(s.tmpDir, "in")
+	handle(c, os.Mkdir(inDir, 0755))
+	tmplPath := filepath.Join(inDir, "in.tmpl")
+	err := ioutil.WriteFile(tmplPath, []byte("hello\n"), 0644)
+	handle(c, err)
+
+	outDir := filepath.Join(s.tmpDir, "out")
+	result := icmd.RunCommand(GomplateBin,
+		"--input-dir", inDir,
+		"--split-on", "\n\n",
+		"--split-name", "section-{{ .index }}.out",
+		"--output-dir", outDir)
+	result.Assert(c, icmd.Expected{ExitCode: 0})
+
+	_, err = os.Stat(filepath.Join(outDir, "section-0.out"))
+	c.Check(err, IsNil)
+}
+
+// TestSplitCollisionIsAnError makes sure two sections that compute the
+// same output path fail loudly instead of silently overwriting one
+// another.
+func (s *SplitSuite) TestSplitCollisionIsAnError(c *C) {
+	inDir := filepath.Join(s.tmpDir, "in")
+	handle(c, os.Mkdir(inDir, 0755))
+	tmplPath := filepath.Join(inDir, "in.tmpl")
+	err := ioutil.WriteFile(tmplPath, []byte("one\n\ntwo\n"), 0644)
+	handle(c, err)
+
+	result := icmd.RunCommand(GomplateBin,
+		"--input-dir", inDir,
+		"--split-on", "\n\n",
+		"--split-name", "same.out",
+		"--output-dir", filepath.Join(s.tmpDir, "out"))
+	result.Assert(c, icmd.Expected{ExitCode: 1})
+	c.Check(result.Combined(), Matches, "(?s).*collision.*")
+}
@@ -0,0 +1,78 @@
+//+build integration
+
+package integration
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type WatchSuite struct {
+	tmpDir string
+}
+
+var _ = Suite(&WatchSuite{})
+
+func (s *WatchSuite) SetUpTest(c *C) {
+	dir, err := ioutil.TempDir("", "gomplate-watch-")
+	handle(c, err)
+	s.tmpDir = dir
+}
+
+func (s *WatchSuite) TearDownTest(c *C) {
+	os.RemoveAll(s.tmpDir)
+}
+
+// TestWatchRerendersOnTemplateChange starts gomplate with --watch against
+// a template file, edits the file, and confirms the output file picks up
+// the change without restarting gomplate. This exercises the debounce
+// timer and the pending-template map concurrently mutated by the
+// fsnotify event loop and the rebuild callback.
+func (s *WatchSuite) TestWatchRerendersOnTemplateChange(c *C) {
+	in := filepath.Join(s.tmpDir, "in.tmpl")
+	out := filepath.Join(s.tmpDir, "out.txt")
+	handle(c, ioutil.WriteFile(in, []byte("v1"), 0644))
+
+	cmd := exec.Command(GomplateBin,
+		"--file", in,
+		"--out", out,
+		"--watch",
+		"--watch-debounce", "20ms")
+	handle(c, cmd.Start())
+	defer cmd.Process.Kill()
+
+	c.Assert(waitForContent(out, "v1", 2*time.Second), IsNil)
+
+	handle(c, ioutil.WriteFile(in, []byte("v2"), 0644))
+	c.Assert(waitForContent(out, "v2", 2*time.Second), IsNil)
+}
+
+func waitForContent(path, want string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	var lastErr error
+	for time.Now().Before(deadline) {
+		b, err := ioutil.ReadFile(path)
+		if err == nil && string(b) == want {
+			return nil
+		}
+		lastErr = err
+		time.Sleep(25 * time.Millisecond)
+	}
+	if lastErr == nil {
+		lastErr = errContentMismatch{path, want}
+	}
+	return lastErr
+}
+
+type errContentMismatch struct {
+	path, want string
+}
+
+func (e errContentMismatch) Error() string {
+	return "timed out waiting for " + e.path + " to contain " + e.want
+}
@@ -0,0 +1,127 @@
+package gomplate
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/pkg/errors"
+)
+
+// watchedPaths returns, for each gathered template, the set of local
+// filesystem paths that affect its output: its own source (when backed by
+// a local file) plus any nested --template aliases it could reference.
+//
+// This only tracks the static inputs gomplate already knows about at
+// gather time. Tracking datasources read via the `ds`/`include`/`file`
+// template funcs would require instrumenting data.Data to record which
+// URLs each render actually touched; that plumbing lives outside this
+// package slice, so dynamic datasource changes aren't picked up yet.
+func watchedPaths(tmpl []*tplate, nested templateAliases) map[string][]*tplate {
+	deps := map[string][]*tplate{}
+	for _, t := range tmpl {
+		if !isRemoteRef(t.name) {
+			if abs, err := filepath.Abs(t.name); err == nil {
+				deps[abs] = append(deps[abs], t)
+			}
+		}
+		for _, pth := range nested {
+			if isRemoteRef(pth) {
+				continue
+			}
+			if abs, err := filepath.Abs(pth); err == nil {
+				deps[abs] = append(deps[abs], t)
+			}
+		}
+	}
+	return deps
+}
+
+// runWatch renders tmpl once (already done by the caller), then keeps
+// rebuilding affected templates as their dependencies change on disk,
+// until the process is interrupted.
+func runWatch(g *gomplate, o *Config, tmpl []*tplate, nested templateAliases) error {
+	// nolint: errcheck
+	fmt.Fprintln(os.Stderr, "gomplate: --watch only tracks changes to template files and --template aliases; "+
+		"changes to datasources read via ds/include/file funcs won't trigger a rebuild")
+
+	deps := watchedPaths(tmpl, nested)
+
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return errors.Wrap(err, "failed to start filesystem watcher")
+	}
+	defer w.Close()
+
+	watchedDirs := map[string]bool{}
+	for pth := range deps {
+		dir := filepath.Dir(pth)
+		if !watchedDirs[dir] {
+			if err := w.Add(dir); err != nil {
+				return errors.Wrapf(err, "failed to watch %s", dir)
+			}
+			watchedDirs[dir] = true
+		}
+	}
+
+	var pendingMu sync.Mutex
+	pending := map[*tplate]bool{}
+	var timer *time.Timer
+	rebuild := func() {
+		pendingMu.Lock()
+		batch := pending
+		pending = map[*tplate]bool{}
+		pendingMu.Unlock()
+
+		failed := false
+		for t := range batch {
+			tstart := time.Now()
+			err := g.runTemplate(t)
+			Metrics.SetRenderDuration(t.name, time.Since(tstart))
+			if err != nil {
+				Metrics.incErrors()
+				failed = true
+				continue
+			}
+			Metrics.incTemplatesProcessed()
+		}
+		if o.RebuildHook != nil && !failed {
+			// nolint: errcheck
+			o.RebuildHook()
+		}
+	}
+
+	for {
+		select {
+		case ev, ok := <-w.Events:
+			if !ok {
+				return nil
+			}
+			abs, err := filepath.Abs(ev.Name)
+			if err != nil {
+				continue
+			}
+			affected, ok := deps[abs]
+			if !ok {
+				continue
+			}
+			pendingMu.Lock()
+			for _, t := range affected {
+				pending[t] = true
+			}
+			pendingMu.Unlock()
+			if timer != nil {
+				timer.Stop()
+			}
+			timer = time.AfterFunc(o.WatchDebounce, rebuild)
+		case err, ok := <-w.Errors:
+			if !ok {
+				return nil
+			}
+			return errors.Wrap(err, "filesystem watcher error")
+		}
+	}
+}